@@ -0,0 +1,103 @@
+// Copyright (c) 2015-2020 The usbtmc developers. All rights reserved.
+// Project site: https://github.com/gotmc/usbtmc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package visa
+
+import (
+	"fmt"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/usbid"
+)
+
+// VidPid identifies a USB device by vendor and product ID.
+type VidPid struct {
+	Vendor  gousb.ID
+	Product gousb.ID
+}
+
+// DiscoveryConfig customizes how ListResourcesWithConfig decides which
+// attached USB devices are instruments and how they're described.
+type DiscoveryConfig struct {
+	// AllowList is treated as TMC-capable even when usbtmc.CheckTMC says
+	// otherwise, for vendor devices that expose TMC-like endpoints under a
+	// proprietary class code.
+	AllowList []VidPid
+	// DenyList is always skipped, even when usbtmc.CheckTMC returns true.
+	DenyList []VidPid
+	// Aliases maps a VidPid to a friendly "manufacturer::model" name to
+	// surface alongside the raw hex IDs.
+	Aliases map[VidPid]string
+}
+
+func (cfg DiscoveryConfig) allowed(vp VidPid) bool {
+	for _, d := range cfg.DenyList {
+		if d == vp {
+			return false
+		}
+	}
+	return true
+}
+
+func (cfg DiscoveryConfig) forcedTmc(vp VidPid) bool {
+	for _, a := range cfg.AllowList {
+		if a == vp {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscoveredResource is one device found by ListResourcesWithConfig.
+type DiscoveredResource struct {
+	Resource   *VisaResource
+	Descriptor string
+	Alias      string
+}
+
+// ListResourcesWithConfig enumerates attached USB devices, applying cfg's
+// allow-list, deny-list, and alias map, and returns a rich record for each
+// instrument found rather than a bare VISA resource string.
+func ListResourcesWithConfig(cfg DiscoveryConfig) []DiscoveredResource {
+	var result []DiscoveredResource
+
+	ctx := gousb.NewContext()
+	defer ctx.Close()
+
+	devs := scanUsbDevices(ctx, func(dev *gousb.Device, candidates []usbAltSetting) {
+		vp := VidPid{Vendor: dev.Desc.Vendor, Product: dev.Desc.Product}
+		if !cfg.allowed(vp) {
+			return
+		}
+
+		sn, err := dev.SerialNumber()
+		if err != nil {
+			return
+		}
+
+		for _, c := range candidates {
+			if !c.isTmc && !cfg.forcedTmc(vp) {
+				continue
+			}
+
+			resourceString := fmt.Sprintf("USB0::0x%s::0x%s::%s::%d::INSTR", dev.Desc.Vendor, dev.Desc.Product, sn, c.ifDesc.Number)
+			visaResource, err := parseVisaResource(resourceString)
+			if err != nil {
+				continue
+			}
+
+			result = append(result, DiscoveredResource{
+				Resource:   visaResource,
+				Descriptor: usbid.Describe(dev.Desc),
+				Alias:      cfg.Aliases[vp],
+			})
+		}
+	})
+	for _, dev := range devs {
+		defer dev.Close()
+	}
+
+	return result
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2015-2020 The usbtmc developers. All rights reserved.
+// Project site: https://github.com/gotmc/usbtmc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package visa
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// HiSLIP (IVI-6.1) message types used on the synchronous channel.
+const (
+	hislipMsgInitialize         = 0
+	hislipMsgInitializeResponse = 1
+	hislipMsgFatalError         = 2
+	hislipMsgError              = 3
+	hislipMsgData               = 6
+	hislipMsgDataEnd            = 7
+
+	hislipDefaultPort    = 4880
+	hislipClientProto    = 1<<8 | 0 // protocol version 1.0
+	hislipHeaderSize     = 16
+	hislipDefaultTimeout = 5 * time.Second
+
+	// hislipMaxMessageSize bounds the payload length readMessage will
+	// allocate for, so a corrupted or hostile length field on the wire
+	// can't force an out-of-memory allocation.
+	hislipMaxMessageSize = 64 * 1024 * 1024
+)
+
+// hislipHeader is the fixed 16-byte HiSLIP message header.
+type hislipHeader struct {
+	msgType   byte
+	control   byte
+	parameter uint32
+	length    uint64
+}
+
+func (h hislipHeader) marshal() []byte {
+	buf := make([]byte, hislipHeaderSize)
+	buf[0] = 'H'
+	buf[1] = 'S'
+	buf[2] = h.msgType
+	buf[3] = h.control
+	binary.BigEndian.PutUint32(buf[4:8], h.parameter)
+	binary.BigEndian.PutUint64(buf[8:16], h.length)
+	return buf
+}
+
+func unmarshalHislipHeader(buf []byte) (hislipHeader, error) {
+	if len(buf) != hislipHeaderSize || buf[0] != 'H' || buf[1] != 'S' {
+		return hislipHeader{}, fmt.Errorf("visa: hislip invalid message header")
+	}
+	return hislipHeader{
+		msgType:   buf[2],
+		control:   buf[3],
+		parameter: binary.BigEndian.Uint32(buf[4:8]),
+		length:    binary.BigEndian.Uint64(buf[8:16]),
+	}, nil
+}
+
+// hislipClient implements Resource over the HiSLIP instrument protocol,
+// using only the synchronous channel (no locking, no SRQ).
+type hislipClient struct {
+	conn      net.Conn
+	sessionID uint16
+	timeout   time.Duration
+}
+
+// newHislipClient connects to hostname and opens a HiSLIP session against
+// the given sub-address (e.g. "hislip0"). port is the TCP port to dial,
+// or -1 to use hislipDefaultPort.
+func newHislipClient(hostname string, subAddress string, port int, opts *sessionOptions) (*hislipClient, error) {
+	if opts.exclusiveLock {
+		return nil, errors.New("visa: exclusive lock is not supported for HiSLIP resources")
+	}
+
+	if subAddress == "" {
+		subAddress = "hislip0"
+	}
+	if port <= 0 {
+		port = hislipDefaultPort
+	}
+
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", hostname, port), hislipDefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("visa: hislip dial: %w", err)
+	}
+
+	timeout := hislipDefaultTimeout
+	if opts.timeout > 0 {
+		timeout = opts.timeout
+	}
+	c := &hislipClient{conn: conn, timeout: timeout}
+
+	if err := c.writeMessage(hislipHeader{
+		msgType:   hislipMsgInitialize,
+		parameter: hislipClientProto << 16,
+	}, []byte(subAddress)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	hdr, _, err := c.readMessage()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("visa: hislip initialize: %w", err)
+	}
+	if hdr.msgType != hislipMsgInitializeResponse {
+		conn.Close()
+		return nil, fmt.Errorf("visa: hislip initialize: unexpected response type %d", hdr.msgType)
+	}
+	c.sessionID = uint16(hdr.parameter & 0xffff)
+
+	return c, nil
+}
+
+func (c *hislipClient) writeMessage(hdr hislipHeader, payload []byte) error {
+	if c.timeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	}
+	hdr.length = uint64(len(payload))
+	if _, err := c.conn.Write(hdr.marshal()); err != nil {
+		return err
+	}
+	if len(payload) > 0 {
+		if _, err := c.conn.Write(payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *hislipClient) readMessage() (hislipHeader, []byte, error) {
+	if c.timeout > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+	}
+	raw := make([]byte, hislipHeaderSize)
+	if _, err := readFull(c.conn, raw); err != nil {
+		return hislipHeader{}, nil, err
+	}
+	hdr, err := unmarshalHislipHeader(raw)
+	if err != nil {
+		return hislipHeader{}, nil, err
+	}
+	if hdr.length > hislipMaxMessageSize {
+		return hdr, nil, fmt.Errorf("visa: hislip message too large: %d bytes", hdr.length)
+	}
+	payload := make([]byte, hdr.length)
+	if hdr.length > 0 {
+		if _, err := readFull(c.conn, payload); err != nil {
+			return hdr, nil, err
+		}
+	}
+	return hdr, payload, nil
+}
+
+func readFull(conn net.Conn, buf []byte) (int, error) {
+	total := 0
+	for total < len(buf) {
+		n, err := conn.Read(buf[total:])
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func (c *hislipClient) Write(p []byte) (int, error) {
+	if err := c.writeMessage(hislipHeader{msgType: hislipMsgDataEnd}, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *hislipClient) Read(p []byte) (int, error) {
+	hdr, payload, err := c.readMessage()
+	if err != nil {
+		return 0, err
+	}
+	if hdr.msgType == hislipMsgFatalError || hdr.msgType == hislipMsgError {
+		return 0, fmt.Errorf("visa: hislip error message, code %d", hdr.control)
+	}
+	return copy(p, payload), nil
+}
+
+// Query writes cmd to the instrument and returns whatever it writes back.
+func (c *hislipClient) Query(cmd string) (string, error) {
+	if _, err := c.Write([]byte(cmd)); err != nil {
+		return "", err
+	}
+	buf := make([]byte, 4096)
+	n, err := c.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return string(buf[:n]), nil
+}
+
+func (c *hislipClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *hislipClient) Timeout() time.Duration {
+	return c.timeout
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2015-2020 The usbtmc developers. All rights reserved.
+// Project site: https://github.com/gotmc/usbtmc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package visa
+
+import "time"
+
+// defaultChunkSize is the largest single bulk transfer size used when no
+// WithChunkSize option is given.
+const defaultChunkSize = 1024 * 1024
+
+// sessionOptions holds the configuration gathered from the Option values
+// passed to OpenResourceWithOptions.
+type sessionOptions struct {
+	timeout        time.Duration
+	chunkSize      int
+	termCharEnable bool
+	termChar       byte
+	exclusiveLock  bool
+	onSRQ          func()
+}
+
+func newSessionOptions() *sessionOptions {
+	return &sessionOptions{
+		chunkSize: defaultChunkSize,
+	}
+}
+
+// Option configures the session OpenResourceWithOptions opens.
+type Option func(*sessionOptions)
+
+// WithTimeout sets the per-transfer I/O timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *sessionOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithChunkSize caps how many bytes are sent in a single bulk transfer;
+// writes larger than chunkSize are split at this boundary.
+func WithChunkSize(chunkSize int) Option {
+	return func(o *sessionOptions) {
+		o.chunkSize = chunkSize
+	}
+}
+
+// WithTermChar enables or disables the termination character and sets its
+// value.
+func WithTermChar(enable bool, char byte) Option {
+	return func(o *sessionOptions) {
+		o.termCharEnable = enable
+		o.termChar = char
+	}
+}
+
+// WithExclusiveLock requests exclusive access to the instrument, failing
+// fast if another process already holds it. It is only honored by
+// transports that can express a lock (currently USBTMC and VXI-11);
+// OpenResourceWithOptions returns an error rather than silently ignoring
+// it for transports that can't.
+func WithExclusiveLock() Option {
+	return func(o *sessionOptions) {
+		o.exclusiveLock = true
+	}
+}
+
+// WithSRQHandler registers fn to be called whenever the instrument raises
+// a Service Request. It is only honored by transports that can deliver
+// SRQ notifications (currently USBTMC, via its interrupt-IN endpoint).
+func WithSRQHandler(fn func()) Option {
+	return func(o *sessionOptions) {
+		o.onSRQ = fn
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2015-2020 The usbtmc developers. All rights reserved.
+// Project site: https://github.com/gotmc/usbtmc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package visa
+
+import (
+	"time"
+
+	"github.com/grvstick/usbtmc"
+)
+
+// Resource is implemented by every instrument transport this module
+// supports (USBTMC, VXI-11, and HiSLIP). OpenResource dispatches on the
+// resource string and hands back a Resource, so callers don't need to
+// care whether the instrument sits on USB or Ethernet.
+type Resource interface {
+	Read(p []byte) (int, error)
+	Write(p []byte) (int, error)
+	Query(cmd string) (string, error)
+	Close() error
+	Timeout() time.Duration
+}
+
+// usbtmcResource adapts a *usbtmc.UsbTmc to the Resource interface.
+type usbtmcResource struct {
+	dev       *usbtmc.UsbTmc
+	timeout   time.Duration
+	chunkSize int
+}
+
+// newUsbtmcResource wires session options into dev. SetTimeout, Lock, and
+// OnSRQ are assumed additions to the github.com/grvstick/usbtmc fork
+// alongside SetTermChar; confirm their signatures against that fork
+// before merging this series.
+func newUsbtmcResource(dev *usbtmc.UsbTmc, opts *sessionOptions) (*usbtmcResource, error) {
+	r := &usbtmcResource{
+		dev:       dev,
+		timeout:   opts.timeout,
+		chunkSize: opts.chunkSize,
+	}
+	if r.chunkSize <= 0 {
+		r.chunkSize = defaultChunkSize
+	}
+
+	dev.SetTermChar(opts.termCharEnable, opts.termChar)
+
+	if opts.timeout > 0 {
+		dev.SetTimeout(opts.timeout)
+	}
+
+	if opts.exclusiveLock {
+		if err := dev.Lock(); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.onSRQ != nil {
+		dev.OnSRQ(opts.onSRQ)
+	}
+
+	return r, nil
+}
+
+func (r *usbtmcResource) Read(p []byte) (int, error) {
+	return r.dev.Read(p)
+}
+
+// Write sends p to the instrument, splitting it into chunkSize-sized bulk
+// transfers if necessary.
+func (r *usbtmcResource) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		end := written + r.chunkSize
+		if end > len(p) {
+			end = len(p)
+		}
+		n, err := r.dev.Write(p[written:end])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// Query writes cmd to the instrument and returns whatever it writes back.
+func (r *usbtmcResource) Query(cmd string) (string, error) {
+	if _, err := r.Write([]byte(cmd)); err != nil {
+		return "", err
+	}
+	buf := make([]byte, 4096)
+	n, err := r.dev.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return string(buf[:n]), nil
+}
+
+func (r *usbtmcResource) Close() error {
+	return r.dev.Close()
+}
+
+func (r *usbtmcResource) Timeout() time.Duration {
+	return r.timeout
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2015-2020 The usbtmc developers. All rights reserved.
+// Project site: https://github.com/gotmc/usbtmc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package visa
+
+import (
+	"fmt"
+
+	"github.com/google/gousb"
+	"github.com/google/gousb/usbid"
+)
+
+// ResourceInfo carries everything ListResourcesDetailed can gather about
+// an attached instrument in a single enumeration pass, so callers building
+// a device picker don't need to re-open the device just to display it.
+type ResourceInfo struct {
+	Resource *VisaResource
+
+	Bus     int
+	Address int
+	Speed   gousb.Speed
+
+	Manufacturer string
+	Product      string
+	SerialNumber string
+
+	// Class is usbid.Classify's human-readable description of the class,
+	// subclass and protocol in the device descriptor; the class of the TMC
+	// interface itself is not described.
+	Class string
+}
+
+// ListResourcesDetailed enumerates attached USB devices and returns a
+// ResourceInfo for every TMC-capable one found. A device whose descriptor
+// strings can't be read still produces a ResourceInfo with whatever fields
+// were readable, rather than being skipped.
+func ListResourcesDetailed() ([]ResourceInfo, error) {
+	var result []ResourceInfo
+
+	ctx := gousb.NewContext()
+	defer ctx.Close()
+
+	devs := scanUsbDevices(ctx, func(dev *gousb.Device, candidates []usbAltSetting) {
+		sn, _ := dev.SerialNumber()
+		manufacturer, _ := dev.Manufacturer()
+		product, _ := dev.Product()
+
+		for _, c := range candidates {
+			if !c.isTmc {
+				continue
+			}
+
+			// Built directly rather than formatted into a VISA resource
+			// string and re-parsed, since an unreadable serial number
+			// (sn == "") would otherwise produce a string that
+			// usbResourceRegexp rejects, defeating the "populate what
+			// we can" contract of this function.
+			visaResource := &VisaResource{
+				resourceString:   fmt.Sprintf("USB0::0x%s::0x%s::%s::%d::INSTR", dev.Desc.Vendor, dev.Desc.Product, sn, c.ifDesc.Number),
+				interfaceType:    interfaceUSB,
+				boardIndex:       0,
+				manufacturerID:   int(dev.Desc.Vendor),
+				modelCode:        int(dev.Desc.Product),
+				serialNumber:     sn,
+				interfaceIndex:   c.ifDesc.Number,
+				resourceClass:    "INSTR",
+				port:             -1,
+				hislipSubaddress: -1,
+			}
+
+			result = append(result, ResourceInfo{
+				Resource:     visaResource,
+				Bus:          dev.Desc.Bus,
+				Address:      dev.Desc.Address,
+				Speed:        dev.Desc.Speed,
+				Manufacturer: manufacturer,
+				Product:      product,
+				SerialNumber: sn,
+				Class:        usbid.Classify(dev.Desc),
+			})
+		}
+	})
+	for _, dev := range devs {
+		defer dev.Close()
+	}
+
+	return result, nil
+}
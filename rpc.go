@@ -0,0 +1,159 @@
+// Copyright (c) 2015-2020 The usbtmc developers. All rights reserved.
+// Project site: https://github.com/gotmc/usbtmc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package visa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+)
+
+// This file implements just enough of ONC RPC (RFC 1057/1831) and XDR
+// (RFC 1014/4506) to drive the VXI-11 core channel and the portmapper:
+// a single synchronous call/reply over an already-connected TCP socket,
+// AUTH_NONE credentials, and no batching.
+
+// rpcXID is shared by every connection a process opens, so it's mutated
+// with atomic.AddUint32 rather than plain incrementing.
+var rpcXID uint32
+
+// rpcCall sends a single RPC request over conn and returns the raw result
+// bytes from a successful reply.
+func rpcCall(conn net.Conn, program, version, procedure uint32, args []byte) ([]byte, error) {
+	xid := atomic.AddUint32(&rpcXID, 1)
+
+	var msg bytes.Buffer
+	xdrPutUint32(&msg, xid)
+	xdrPutUint32(&msg, 0) // mtype = CALL
+	xdrPutUint32(&msg, 2) // rpcvers
+	xdrPutUint32(&msg, program)
+	xdrPutUint32(&msg, version)
+	xdrPutUint32(&msg, procedure)
+	xdrPutUint32(&msg, 0) // cred flavor = AUTH_NONE
+	xdrPutUint32(&msg, 0) // cred length
+	xdrPutUint32(&msg, 0) // verf flavor = AUTH_NONE
+	xdrPutUint32(&msg, 0) // verf length
+	msg.Write(args)
+
+	if err := rpcWriteFragment(conn, msg.Bytes()); err != nil {
+		return nil, err
+	}
+
+	reply, err := rpcReadFragment(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	r := bytes.NewReader(reply)
+	replyXID, _ := xdrGetUint32(r)
+	if replyXID != xid {
+		return nil, fmt.Errorf("visa: rpc xid mismatch: got %d want %d", replyXID, xid)
+	}
+	mtype, _ := xdrGetUint32(r)
+	if mtype != 1 {
+		return nil, errors.New("visa: rpc reply is not a REPLY message")
+	}
+	replyStat, _ := xdrGetUint32(r)
+	if replyStat != 0 {
+		return nil, fmt.Errorf("visa: rpc call denied, stat %d", replyStat)
+	}
+	// verf flavor + length
+	_, _ = xdrGetUint32(r)
+	verfLen, _ := xdrGetUint32(r)
+	if verfLen > 0 {
+		skip := make([]byte, xdrPad(verfLen))
+		_, _ = io.ReadFull(r, skip)
+	}
+	acceptStat, _ := xdrGetUint32(r)
+	if acceptStat != 0 {
+		return nil, fmt.Errorf("visa: rpc call failed, accept stat %d", acceptStat)
+	}
+
+	rest := make([]byte, r.Len())
+	_, _ = io.ReadFull(r, rest)
+	return rest, nil
+}
+
+// rpcWriteFragment writes b as a single, final record-marked fragment.
+func rpcWriteFragment(conn net.Conn, b []byte) error {
+	header := uint32(len(b)) | 0x80000000
+	if err := binary.Write(conn, binary.BigEndian, header); err != nil {
+		return err
+	}
+	_, err := conn.Write(b)
+	return err
+}
+
+// rpcReadFragment reads one or more record-marked fragments and returns the
+// reassembled message.
+func rpcReadFragment(conn net.Conn) ([]byte, error) {
+	var out bytes.Buffer
+	for {
+		var header uint32
+		if err := binary.Read(conn, binary.BigEndian, &header); err != nil {
+			return nil, err
+		}
+		last := header&0x80000000 != 0
+		length := header &^ 0x80000000
+
+		if _, err := io.CopyN(&out, conn, int64(length)); err != nil {
+			return nil, err
+		}
+		if last {
+			break
+		}
+	}
+	return out.Bytes(), nil
+}
+
+func xdrPad(n uint32) uint32 {
+	if rem := n % 4; rem != 0 {
+		return n + (4 - rem)
+	}
+	return n
+}
+
+func xdrPutUint32(buf *bytes.Buffer, v uint32) {
+	_ = binary.Write(buf, binary.BigEndian, v)
+}
+
+func xdrPutBytes(buf *bytes.Buffer, b []byte) {
+	xdrPutUint32(buf, uint32(len(b)))
+	buf.Write(b)
+	if pad := xdrPad(uint32(len(b))) - uint32(len(b)); pad > 0 {
+		buf.Write(make([]byte, pad))
+	}
+}
+
+func xdrPutString(buf *bytes.Buffer, s string) {
+	xdrPutBytes(buf, []byte(s))
+}
+
+func xdrGetUint32(r *bytes.Reader) (uint32, error) {
+	var v uint32
+	err := binary.Read(r, binary.BigEndian, &v)
+	return v, err
+}
+
+func xdrGetBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := xdrGetUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	if pad := xdrPad(n) - n; pad > 0 {
+		skip := make([]byte, pad)
+		_, _ = io.ReadFull(r, skip)
+	}
+	return b, nil
+}
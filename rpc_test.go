@@ -0,0 +1,121 @@
+// Copyright (c) 2015-2020 The usbtmc developers. All rights reserved.
+// Project site: https://github.com/gotmc/usbtmc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package visa
+
+import (
+	"bytes"
+	"net"
+	"sync"
+	"testing"
+)
+
+// rpcReply builds a well-formed RPC reply fragment accepting the call with
+// xid, carrying result as the call-specific payload.
+func rpcReply(xid uint32, result []byte) []byte {
+	var msg bytes.Buffer
+	xdrPutUint32(&msg, xid)
+	xdrPutUint32(&msg, 1) // mtype = REPLY
+	xdrPutUint32(&msg, 0) // reply stat = MSG_ACCEPTED
+	xdrPutUint32(&msg, 0) // verf flavor = AUTH_NONE
+	xdrPutUint32(&msg, 0) // verf length
+	xdrPutUint32(&msg, 0) // accept stat = SUCCESS
+	msg.Write(result)
+	return msg.Bytes()
+}
+
+// serveOneRpcCall reads a single request fragment off conn, extracts its
+// xid, and replies with a successful result. It's run in a goroutine to
+// stand in for the server side of the net.Conn rpcCall talks to.
+func serveOneRpcCall(t *testing.T, conn net.Conn, result []byte) {
+	t.Helper()
+	req, err := rpcReadFragment(conn)
+	if err != nil {
+		t.Errorf("server: rpcReadFragment: %v", err)
+		return
+	}
+	xid, _ := xdrGetUint32(bytes.NewReader(req))
+	if err := rpcWriteFragment(conn, rpcReply(xid, result)); err != nil {
+		t.Errorf("server: rpcWriteFragment: %v", err)
+	}
+}
+
+func TestRpcCall(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	want := []byte("result payload")
+	go serveOneRpcCall(t, server, want)
+
+	got, err := rpcCall(client, 1, 1, 1, nil)
+	if err != nil {
+		t.Fatalf("rpcCall: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("rpcCall result = %q, want %q", got, want)
+	}
+}
+
+func TestRpcCallXidMismatch(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	go func() {
+		if _, err := rpcReadFragment(server); err != nil {
+			return
+		}
+		// Reply with the wrong xid so rpcCall must reject it.
+		_ = rpcWriteFragment(server, rpcReply(0xdeadbeef, nil))
+	}()
+
+	if _, err := rpcCall(client, 1, 1, 1, nil); err == nil {
+		t.Fatal("expected an xid mismatch error, got nil")
+	}
+}
+
+// TestRpcXidConcurrentCallsUnique exercises rpcXID's atomic increment:
+// concurrent callers must never observe the same xid, which plain
+// increment (rpcXID++) can't guarantee under -race.
+func TestRpcXidConcurrentCallsUnique(t *testing.T) {
+	const n = 50
+	var wg sync.WaitGroup
+	xids := make(chan uint32, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+
+			go func() {
+				req, err := rpcReadFragment(server)
+				if err != nil {
+					return
+				}
+				xid, _ := xdrGetUint32(bytes.NewReader(req))
+				xids <- xid
+				_ = rpcWriteFragment(server, rpcReply(xid, nil))
+			}()
+
+			if _, err := rpcCall(client, 1, 1, 1, nil); err != nil {
+				t.Errorf("rpcCall: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(xids)
+
+	seen := map[uint32]bool{}
+	for xid := range xids {
+		if seen[xid] {
+			t.Errorf("duplicate xid %d observed across concurrent rpcCalls", xid)
+		}
+		seen[xid] = true
+	}
+}
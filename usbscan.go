@@ -0,0 +1,61 @@
+// Copyright (c) 2015-2020 The usbtmc developers. All rights reserved.
+// Project site: https://github.com/gotmc/usbtmc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package visa
+
+import (
+	"github.com/google/gousb"
+	"github.com/grvstick/usbtmc"
+)
+
+// usbAltSetting is one (interface, alt setting) combination found on an
+// enumerated USB device, together with what usbtmc.CheckTMC decided about
+// it. Callers that want to second-guess CheckTMC (e.g. an allow-list of
+// vendor devices that don't self-identify as TMC) can use isTmc as a
+// starting point rather than a final answer.
+type usbAltSetting struct {
+	ifDesc gousb.InterfaceDesc
+	isTmc  bool
+}
+
+// scanUsbDevices opens every attached USB device and, for each one whose
+// active configuration can be read, calls fn with the device and its
+// interface/alt-setting combinations. Devices whose active config can't be
+// read are closed and skipped entirely, matching what every caller of this
+// did before it was factored out. Every device passed to fn is returned
+// still open; closing it (immediately inside fn, or later via defer) is
+// left to the caller, since callers differ on whether they want to hold
+// the device open past fn returning.
+func scanUsbDevices(ctx *gousb.Context, fn func(dev *gousb.Device, candidates []usbAltSetting)) []*gousb.Device {
+	devs, _ := ctx.OpenDevices(func(desc *gousb.DeviceDesc) bool {
+		return true
+	})
+
+	var scanned []*gousb.Device
+	for _, dev := range devs {
+		activeCfg, err := dev.ActiveConfigNum()
+		if err != nil {
+			dev.Close()
+			continue
+		}
+		cfg, err := dev.Config(activeCfg)
+		if err != nil {
+			dev.Close()
+			continue
+		}
+
+		var candidates []usbAltSetting
+		for _, ifDesc := range cfg.Desc.Interfaces {
+			for _, alt := range ifDesc.AltSettings {
+				isTmc, _ := usbtmc.CheckTMC(alt)
+				candidates = append(candidates, usbAltSetting{ifDesc: ifDesc, isTmc: isTmc})
+			}
+		}
+		fn(dev, candidates)
+		scanned = append(scanned, dev)
+	}
+
+	return scanned
+}
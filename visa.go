@@ -16,6 +16,12 @@ import (
 	"github.com/grvstick/usbtmc"
 )
 
+// Supported VISA interface types.
+const (
+	interfaceUSB   = "USB"
+	interfaceTCPIP = "TCPIP"
+)
+
 // VisaResource represents a VISA enabled piece of test equipment.
 
 type VisaResource struct {
@@ -27,39 +33,81 @@ type VisaResource struct {
 	serialNumber   string
 	interfaceIndex int
 	resourceClass  string
+
+	// The following fields are only populated for TCPIP resources, i.e.
+	// VXI-11 and HiSLIP instruments reached over Ethernet rather than USB.
+	hostname string
+	lanName  string
+	// port is the TCP port parsed out of a `TCPIP[board]::host::port::hislipN::INSTR`
+	// resource string. It is -1 when the resource string didn't specify one,
+	// in which case the transport's default port applies.
+	port int
+	// hislipSubaddress is the sub-address parsed out of a "hislipN" LAN
+	// device name. It is -1 for USB and VXI-11 resources.
+	hislipSubaddress int
 }
 
-// parseVisaResource creates a new VisaResource using the given VISA resourceString.
-func parseVisaResource(resourceString string) (*VisaResource, error) {
-	visa := &VisaResource{
-		resourceString: resourceString,
-		interfaceType:  "",
-		boardIndex:     -1,
-		manufacturerID: -1,
-		modelCode:      -1,
-		serialNumber:   "",
-		interfaceIndex: -1,
-		resourceClass:  "",
-	}
-	regString := `^(?P<interfaceType>[A-Za-z]+)(?P<boardIndex>\d*)::` +
+var usbResourceRegexp = regexp.MustCompile(
+	`^(?P<interfaceType>USB)(?P<boardIndex>\d*)::` +
 		`(?P<manufacturerID>[^\s:]+)::` +
 		`(?P<modelCode>[^\s:]+)` +
 		`(::(?P<serialNumber>[^\s:]+))?` +
 		`(::(?P<interfaceIndex>\d*))` +
-		`::(?P<resourceClass>[^\s:]+)$`
+		`::(?P<resourceClass>[^\s:]+)$`)
+
+// tcpipResourceRegexp covers both VXI-11 resource strings, which have at
+// most one optional segment between the host and the resource class (the
+// LAN device name, e.g. "inst0" or "gpib0,2"), and HiSLIP resource
+// strings, which may have two (an optional port, then the "hislipN"
+// sub-address). segment1/segment2 are disambiguated in parseTcpipResource.
+var tcpipResourceRegexp = regexp.MustCompile(
+	`^(?P<interfaceType>TCPIP)(?P<boardIndex>\d*)::` +
+		`(?P<hostname>[^\s:]+)` +
+		`(::(?P<segment1>[^\s:]+))?` +
+		`(::(?P<segment2>[^\s:]+))?` +
+		`::(?P<resourceClass>[^\s:]+)$`)
+
+var hislipNameRegexp = regexp.MustCompile(`(?i)^hislip(\d*)`)
 
-	re := regexp.MustCompile(regString)
-	res := re.FindStringSubmatch(resourceString)
+// parseVisaResource creates a new VisaResource using the given VISA resourceString.
+func parseVisaResource(resourceString string) (*VisaResource, error) {
+	visa := &VisaResource{
+		resourceString:   resourceString,
+		interfaceType:    "",
+		boardIndex:       -1,
+		manufacturerID:   -1,
+		modelCode:        -1,
+		serialNumber:     "",
+		interfaceIndex:   -1,
+		resourceClass:    "",
+		port:             -1,
+		hislipSubaddress: -1,
+	}
+
+	switch {
+	case usbResourceRegexp.MatchString(resourceString):
+		return parseUsbResource(visa, resourceString)
+	case tcpipResourceRegexp.MatchString(resourceString):
+		return parseTcpipResource(visa, resourceString)
+	default:
+		return visa, errors.New("visa: unrecognized or unsupported resource string")
+	}
+}
+
+func reSubmatchMap(re *regexp.Regexp, s string) map[string]string {
+	res := re.FindStringSubmatch(s)
 	subexpNames := re.SubexpNames()
 	matchMap := map[string]string{}
 	for i, n := range res {
 		matchMap[subexpNames[i]] = string(n)
 	}
+	return matchMap
+}
 
-	if strings.ToUpper(matchMap["interfaceType"]) != "USB" {
-		return visa, errors.New("visa: interface type was not usb")
-	}
-	visa.interfaceType = "USB"
+func parseUsbResource(visa *VisaResource, resourceString string) (*VisaResource, error) {
+	matchMap := reSubmatchMap(usbResourceRegexp, resourceString)
+
+	visa.interfaceType = interfaceUSB
 
 	if matchMap["boardIndex"] != "" {
 		boardIndex, err := strconv.ParseUint(matchMap["boardIndex"], 0, 16)
@@ -101,7 +149,65 @@ func parseVisaResource(resourceString string) (*VisaResource, error) {
 	visa.resourceClass = "INSTR"
 
 	return visa, nil
+}
+
+func parseTcpipResource(visa *VisaResource, resourceString string) (*VisaResource, error) {
+	matchMap := reSubmatchMap(tcpipResourceRegexp, resourceString)
+
+	visa.interfaceType = interfaceTCPIP
+
+	if matchMap["boardIndex"] != "" {
+		boardIndex, err := strconv.ParseUint(matchMap["boardIndex"], 0, 16)
+		if err != nil {
+			return visa, errors.New("visa: boardIndex error")
+		}
+		visa.boardIndex = int(boardIndex)
+	}
+
+	if matchMap["hostname"] == "" {
+		return visa, errors.New("visa: hostname missing")
+	}
+	visa.hostname = matchMap["hostname"]
+
+	// segment2 is only present when an optional port precedes the LAN
+	// device name (the HiSLIP "host::port::hislipN" grammar); otherwise
+	// segment1 is the LAN device name itself. The two-segment form only
+	// exists for HiSLIP, so reject it outright if segment2 isn't a
+	// "hislipN" name rather than silently routing it to VXI-11 and
+	// dropping the parsed port.
+	visa.port = -1
+	if matchMap["segment2"] != "" {
+		if !hislipNameRegexp.MatchString(matchMap["segment2"]) {
+			return visa, errors.New("visa: port segment is only valid for hislip resources")
+		}
+		port, err := strconv.Atoi(matchMap["segment1"])
+		if err != nil {
+			return visa, errors.New("visa: port error")
+		}
+		visa.port = port
+		visa.lanName = matchMap["segment2"]
+	} else {
+		visa.lanName = matchMap["segment1"]
+	}
 
+	if m := hislipNameRegexp.FindStringSubmatch(visa.lanName); m != nil {
+		subaddress := 0
+		if m[1] != "" {
+			n, err := strconv.Atoi(m[1])
+			if err != nil {
+				return visa, errors.New("visa: hislipSubaddress error")
+			}
+			subaddress = n
+		}
+		visa.hislipSubaddress = subaddress
+	}
+
+	if strings.ToUpper(matchMap["resourceClass"]) != "INSTR" {
+		return visa, errors.New("visa: resource class was not instr")
+	}
+	visa.resourceClass = "INSTR"
+
+	return visa, nil
 }
 
 // NewDevice searches for device matching vid, pid and serial number. Serial number can be omitted by passing empty string
@@ -110,62 +216,66 @@ func parseVisaResource(resourceString string) (*VisaResource, error) {
 func ListResources() []string {
 	var result []string
 
-	// Iterate through available devices. Find all devices that match the given
-	// Vendor ID and Product ID.
 	ctx := gousb.NewContext()
 	defer ctx.Close()
 
-	devs, _ := ctx.OpenDevices(func(desc *gousb.DeviceDesc) bool {
-		// This anonymous function is called for every device present. Returning
-		// true means the device should be opened.
-		return true
-	})
-	for _, d := range devs {
-		defer d.Close()
-	}
-
-	for _, dev := range devs {
-
+	devs := scanUsbDevices(ctx, func(dev *gousb.Device, candidates []usbAltSetting) {
 		sn, err := dev.SerialNumber()
-
-		if err != nil {
-			continue
-		}
-		activeCfg, err := dev.ActiveConfigNum()
-		if err != nil {
-			continue
-		}
-		cfg, err := dev.Config(activeCfg)
 		if err != nil {
-			continue
+			return
 		}
-		for _, ifDesc := range cfg.Desc.Interfaces {
-			for _, alt := range ifDesc.AltSettings {
-				isTmc, _ := usbtmc.CheckTMC(alt)
-
-				if isTmc {
-					result = append(result, fmt.Sprintf("USB0::0x%s::0x%s::%s::%d::INSTR", dev.Desc.Vendor, dev.Desc.Product, sn, ifDesc.Number))
-				}
+		for _, c := range candidates {
+			if !c.isTmc {
+				continue
 			}
+			result = append(result, fmt.Sprintf("USB0::0x%s::0x%s::%s::%d::INSTR", dev.Desc.Vendor, dev.Desc.Product, sn, c.ifDesc.Number))
 		}
-
+	})
+	for _, d := range devs {
+		defer d.Close()
 	}
 
 	return result
 }
 
-func OpenResource(addr string, termchar byte) (*usbtmc.UsbTmc, error) {
+// OpenResource opens the instrument identified by addr and returns a
+// Resource backed by whichever transport the resource string names
+// (USBTMC, VXI-11, or HiSLIP), using termchar as the termination
+// character. This is a thin wrapper around OpenResourceWithOptions that
+// keeps the two-argument form; it returns a Resource, not a
+// *usbtmc.UsbTmc. Callers wanting the other Option values should call
+// OpenResourceWithOptions directly.
+func OpenResource(addr string, termchar byte) (Resource, error) {
+	return OpenResourceWithOptions(addr, WithTermChar(true, termchar))
+}
+
+// OpenResourceWithOptions opens the instrument identified by addr and
+// returns a Resource backed by whichever transport the resource string
+// names (USBTMC, VXI-11, or HiSLIP), configured by opts.
+func OpenResourceWithOptions(addr string, opts ...Option) (Resource, error) {
 	v, err := parseVisaResource(addr)
 	if err != nil {
 		return nil, err
 	}
 
-	dev, err := usbtmc.NewDevice(v.manufacturerID, v.modelCode, v.serialNumber)
-
-	if err != nil {
-		return nil, err
+	o := newSessionOptions()
+	for _, opt := range opts {
+		opt(o)
 	}
 
-	return usbtmc.NewUsbTmc(dev, termchar), nil
-
+	switch v.interfaceType {
+	case interfaceUSB:
+		dev, err := usbtmc.NewDevice(v.manufacturerID, v.modelCode, v.serialNumber)
+		if err != nil {
+			return nil, err
+		}
+		return newUsbtmcResource(usbtmc.NewUsbTmc(dev, o.termChar), o)
+	case interfaceTCPIP:
+		if v.hislipSubaddress >= 0 {
+			return newHislipClient(v.hostname, v.lanName, v.port, o)
+		}
+		return newVxi11Client(v.hostname, v.lanName, o)
+	default:
+		return nil, fmt.Errorf("visa: unsupported interface type %q", v.interfaceType)
+	}
 }
@@ -0,0 +1,77 @@
+// Copyright (c) 2015-2020 The usbtmc developers. All rights reserved.
+// Project site: https://github.com/gotmc/usbtmc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package visa
+
+import "testing"
+
+func TestParseVisaResourceUSB(t *testing.T) {
+	v, err := parseVisaResource("USB0::0x1234::0x5678::SN123::0::INSTR")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.interfaceType != interfaceUSB {
+		t.Errorf("interfaceType = %q, want %q", v.interfaceType, interfaceUSB)
+	}
+	if v.manufacturerID != 0x1234 || v.modelCode != 0x5678 {
+		t.Errorf("manufacturerID/modelCode = %#x/%#x, want 0x1234/0x5678", v.manufacturerID, v.modelCode)
+	}
+	if v.serialNumber != "SN123" {
+		t.Errorf("serialNumber = %q, want SN123", v.serialNumber)
+	}
+}
+
+func TestParseVisaResourceVxi11(t *testing.T) {
+	v, err := parseVisaResource("TCPIP0::192.168.1.1::gpib0,2::INSTR")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.interfaceType != interfaceTCPIP {
+		t.Errorf("interfaceType = %q, want %q", v.interfaceType, interfaceTCPIP)
+	}
+	if v.lanName != "gpib0,2" {
+		t.Errorf("lanName = %q, want gpib0,2", v.lanName)
+	}
+	if v.port != -1 {
+		t.Errorf("port = %d, want -1", v.port)
+	}
+	if v.hislipSubaddress != -1 {
+		t.Errorf("hislipSubaddress = %d, want -1", v.hislipSubaddress)
+	}
+}
+
+func TestParseVisaResourceHislipWithPort(t *testing.T) {
+	v, err := parseVisaResource("TCPIP0::192.168.1.1::5000::hislip1::INSTR")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.port != 5000 {
+		t.Errorf("port = %d, want 5000", v.port)
+	}
+	if v.lanName != "hislip1" {
+		t.Errorf("lanName = %q, want hislip1", v.lanName)
+	}
+	if v.hislipSubaddress != 1 {
+		t.Errorf("hislipSubaddress = %d, want 1", v.hislipSubaddress)
+	}
+}
+
+// TestParseVisaResourceRejectsNonHislipPortSegment guards against a two
+// segment TCPIP resource string being silently routed to VXI-11 with its
+// port segment dropped just because the LAN device name isn't a
+// recognized "hislipN" name.
+func TestParseVisaResourceRejectsNonHislipPortSegment(t *testing.T) {
+	_, err := parseVisaResource("TCPIP0::host::5000::gpib0,2::INSTR")
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+}
+
+func TestParseVisaResourceUnrecognized(t *testing.T) {
+	_, err := parseVisaResource("GPIB0::1::INSTR")
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+}
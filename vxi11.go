@@ -0,0 +1,197 @@
+// Copyright (c) 2015-2020 The usbtmc developers. All rights reserved.
+// Project site: https://github.com/gotmc/usbtmc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package visa
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// VXI-11 core channel program/version numbers, per the VXI-11 spec
+// (VMEbus Extensions for Instrumentation, TCP/IP Instrument Protocol).
+const (
+	vxi11CoreProgram = 0x0607AF
+	vxi11CoreVersion = 1
+
+	vxi11ProcCreateLink  = 10
+	vxi11ProcDeviceWrite = 11
+	vxi11ProcDeviceRead  = 12
+	vxi11ProcDestroyLink = 23
+
+	portmapperPort    = 111
+	portmapperProgram = 100000
+	portmapperVersion = 2
+	portmapperGetPort = 3
+
+	vxi11DefaultTimeout = 5 * time.Second
+)
+
+// vxi11Client implements Resource over the VXI-11 network instrument
+// protocol.
+type vxi11Client struct {
+	conn    net.Conn
+	linkID  int32
+	maxRecv uint32
+	xid     uint32
+	timeout time.Duration
+}
+
+// newVxi11Client connects to hostname and creates a VXI-11 link to
+// lanDeviceName (e.g. "inst0" or "gpib0,2").
+func newVxi11Client(hostname, lanDeviceName string, opts *sessionOptions) (*vxi11Client, error) {
+	if lanDeviceName == "" {
+		lanDeviceName = "inst0"
+	}
+
+	port, err := vxi11LookupPort(hostname, vxi11CoreProgram, vxi11CoreVersion)
+	if err != nil {
+		return nil, fmt.Errorf("visa: vxi11 portmapper lookup: %w", err)
+	}
+
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", hostname, port), vxi11DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("visa: vxi11 dial: %w", err)
+	}
+
+	timeout := vxi11DefaultTimeout
+	if opts.timeout > 0 {
+		timeout = opts.timeout
+	}
+	c := &vxi11Client{conn: conn, timeout: timeout}
+
+	var args bytes.Buffer
+	xdrPutUint32(&args, 0) // clientId, unused
+	if opts.exclusiveLock {
+		xdrPutUint32(&args, 1)
+	} else {
+		xdrPutUint32(&args, 0)
+	}
+	xdrPutUint32(&args, uint32(c.timeout/time.Millisecond))
+	xdrPutString(&args, lanDeviceName)
+
+	reply, err := c.call(vxi11ProcCreateLink, args.Bytes())
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("visa: vxi11 create_link: %w", err)
+	}
+	r := bytes.NewReader(reply)
+	errCode, _ := xdrGetUint32(r)
+	linkID, _ := xdrGetUint32(r)
+	maxRecv, _ := xdrGetUint32(r)
+	if errCode != 0 {
+		conn.Close()
+		return nil, fmt.Errorf("visa: vxi11 create_link error code %d", errCode)
+	}
+	c.linkID = int32(linkID)
+	c.maxRecv = maxRecv
+	if c.maxRecv == 0 {
+		c.maxRecv = 4096
+	}
+
+	return c, nil
+}
+
+func (c *vxi11Client) Write(p []byte) (int, error) {
+	var args bytes.Buffer
+	xdrPutUint32(&args, uint32(c.linkID))
+	xdrPutUint32(&args, 0) // flags
+	xdrPutUint32(&args, uint32(c.timeout/time.Millisecond))
+	xdrPutUint32(&args, uint32(c.timeout/time.Millisecond))
+	xdrPutBytes(&args, p)
+
+	reply, err := c.call(vxi11ProcDeviceWrite, args.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	r := bytes.NewReader(reply)
+	errCode, _ := xdrGetUint32(r)
+	size, _ := xdrGetUint32(r)
+	if errCode != 0 {
+		return int(size), fmt.Errorf("visa: vxi11 device_write error code %d", errCode)
+	}
+	return int(size), nil
+}
+
+func (c *vxi11Client) Read(p []byte) (int, error) {
+	var args bytes.Buffer
+	xdrPutUint32(&args, uint32(c.linkID))
+	xdrPutUint32(&args, uint32(len(p)))
+	xdrPutUint32(&args, 0) // flags
+	xdrPutUint32(&args, uint32(c.timeout/time.Millisecond))
+	xdrPutUint32(&args, uint32(c.timeout/time.Millisecond))
+	xdrPutUint32(&args, 0) // termChar
+
+	reply, err := c.call(vxi11ProcDeviceRead, args.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	r := bytes.NewReader(reply)
+	errCode, _ := xdrGetUint32(r)
+	_, _ = xdrGetUint32(r) // reason
+	data, _ := xdrGetBytes(r)
+	if errCode != 0 {
+		return 0, fmt.Errorf("visa: vxi11 device_read error code %d", errCode)
+	}
+	return copy(p, data), nil
+}
+
+// Query writes cmd to the instrument and returns whatever it writes back.
+func (c *vxi11Client) Query(cmd string) (string, error) {
+	if _, err := c.Write([]byte(cmd)); err != nil {
+		return "", err
+	}
+	buf := make([]byte, c.maxRecv)
+	n, err := c.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return string(buf[:n]), nil
+}
+
+func (c *vxi11Client) Close() error {
+	var args bytes.Buffer
+	xdrPutUint32(&args, uint32(c.linkID))
+	_, _ = c.call(vxi11ProcDestroyLink, args.Bytes())
+	return c.conn.Close()
+}
+
+func (c *vxi11Client) Timeout() time.Duration {
+	return c.timeout
+}
+
+// vxi11LookupPort asks the remote portmapper (RFC 1057) which port the
+// given RPC program/version is listening on.
+func vxi11LookupPort(hostname string, program, version uint32) (int, error) {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", hostname, portmapperPort), vxi11DefaultTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var args bytes.Buffer
+	xdrPutUint32(&args, program)
+	xdrPutUint32(&args, version)
+	xdrPutUint32(&args, 6) // IPPROTO_TCP
+	xdrPutUint32(&args, 0) // port, unused for the query
+
+	reply, err := rpcCall(conn, portmapperProgram, portmapperVersion, portmapperGetPort, args.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	port, _ := xdrGetUint32(bytes.NewReader(reply))
+	if port == 0 {
+		return 0, errors.New("visa: vxi11 program not registered")
+	}
+	return int(port), nil
+}
+
+// call issues an RPC to the VXI-11 core channel already connected to c.conn.
+func (c *vxi11Client) call(procedure uint32, args []byte) ([]byte, error) {
+	return rpcCall(c.conn, vxi11CoreProgram, vxi11CoreVersion, procedure, args)
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2015-2020 The usbtmc developers. All rights reserved.
+// Project site: https://github.com/gotmc/usbtmc
+// Use of this source code is governed by a MIT-style license that
+// can be found in the LICENSE.txt file for the project.
+
+package visa
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/gousb"
+)
+
+// ResourceEventKind distinguishes a device appearing from one disappearing.
+type ResourceEventKind int
+
+const (
+	// ResourceAdded is emitted the first time a TMC-capable device is seen.
+	ResourceAdded ResourceEventKind = iota
+	// ResourceRemoved is emitted once a previously seen device disappears.
+	ResourceRemoved
+)
+
+// ResourceEvent describes a USBTMC instrument being plugged in or unplugged.
+type ResourceEvent struct {
+	Kind     ResourceEventKind
+	Resource string
+}
+
+const defaultWatchInterval = 1 * time.Second
+
+// WatchOption configures Watch.
+type WatchOption func(*watchConfig)
+
+type watchConfig struct {
+	interval time.Duration
+}
+
+// WithPollInterval overrides how often Watch polls for USB device changes.
+func WithPollInterval(interval time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		c.interval = interval
+	}
+}
+
+// usbKey uniquely identifies a USB device across polls, since the bus
+// address alone is reused as devices come and go.
+type usbKey struct {
+	bus, address int
+	vid, pid     gousb.ID
+	serial       string
+}
+
+// Watch polls for USBTMC instruments being plugged in or unplugged and
+// reports them as ResourceEvent values on the returned channel. Polling
+// stops, and the channel is closed, when ctx is canceled.
+func Watch(ctx context.Context, opts ...WatchOption) (<-chan ResourceEvent, error) {
+	cfg := watchConfig{interval: defaultWatchInterval}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	usbCtx := gousb.NewContext()
+
+	events := make(chan ResourceEvent)
+
+	go func() {
+		defer usbCtx.Close()
+		defer close(events)
+
+		seen := map[usbKey]string{}
+		ticker := time.NewTicker(cfg.interval)
+		defer ticker.Stop()
+
+		for {
+			current := scanTmcDevices(usbCtx)
+
+			for key, resource := range current {
+				if _, ok := seen[key]; !ok {
+					select {
+					case events <- ResourceEvent{Kind: ResourceAdded, Resource: resource}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			for key, resource := range seen {
+				if _, ok := current[key]; !ok {
+					select {
+					case events <- ResourceEvent{Kind: ResourceRemoved, Resource: resource}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			seen = current
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// scanTmcDevices enumerates every attached USB device and returns the
+// TMC-capable ones, keyed so callers can diff consecutive scans. Unlike
+// the other scanUsbDevices callers, it closes each device immediately
+// once it's done with it rather than holding it open, since this runs on
+// every tick of the poll loop.
+func scanTmcDevices(usbCtx *gousb.Context) map[usbKey]string {
+	current := map[usbKey]string{}
+
+	scanUsbDevices(usbCtx, func(dev *gousb.Device, candidates []usbAltSetting) {
+		defer dev.Close()
+
+		sn, err := dev.SerialNumber()
+		if err != nil {
+			return
+		}
+		for _, c := range candidates {
+			if !c.isTmc {
+				continue
+			}
+			key := usbKey{
+				bus:     dev.Desc.Bus,
+				address: dev.Desc.Address,
+				vid:     dev.Desc.Vendor,
+				pid:     dev.Desc.Product,
+				serial:  sn,
+			}
+			current[key] = fmt.Sprintf("USB0::0x%s::0x%s::%s::%d::INSTR", dev.Desc.Vendor, dev.Desc.Product, sn, c.ifDesc.Number)
+		}
+	})
+
+	return current
+}